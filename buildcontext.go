@@ -0,0 +1,21 @@
+package which
+
+import "go/build"
+
+// buildContext is the go/build.Context used to resolve import paths
+// in genpkgpath's GOROOT/GOPATH checks. It defaults to a copy of
+// build.Default, which already consults $GOROOT, $GOPATH, and
+// $GOFLAGS the same way the go command does, and handles the
+// platform's path-list separator ($GOPATH is colon-separated on
+// Unix but semicolon-separated on Windows).
+var buildContext = build.Default
+
+// SetBuildContext points import path resolution (used by Exec.Import's
+// pclntab-heuristic fallback, via genpkgpath) at ctxt instead of
+// go/build.Default. This is useful when cross-inspecting a binary
+// that was built against a different GOROOT/GOPATH than the one the
+// calling process is running under, eg a container's Go install
+// examined from the host.
+func SetBuildContext(ctxt *build.Context) {
+	buildContext = *ctxt
+}
@@ -0,0 +1,24 @@
+package which
+
+import "debug/buildinfo"
+
+// BuildInfo returns the module and build information that the Go
+// linker embedded in the executable, as read by debug/buildinfo.
+// This succeeds for binaries built by Go 1.13 or later; for anything
+// older it returns the error from debug/buildinfo.ReadFile (or
+// debug/buildinfo.Read, for an Exec built from a reader rather than
+// a path). A binary built in GOPATH mode has no module to report, so
+// its BuildInfo has an empty Path. The vcs.* and other build
+// settings are only recorded by Go 1.18 or later.
+func (ex *Exec) BuildInfo() (*buildinfo.BuildInfo, error) {
+	if ex.r != nil {
+		return buildinfo.Read(ex.r)
+	}
+	return buildinfo.ReadFile(ex.Path)
+}
+
+// BuildInfo reads the module and build information embedded in the
+// Go executable at path. See Exec.BuildInfo.
+func BuildInfo(path string) (*buildinfo.BuildInfo, error) {
+	return buildinfo.ReadFile(path)
+}
@@ -0,0 +1,122 @@
+package which
+
+import (
+	"debug/elf"
+	"io"
+)
+
+type elftbl struct {
+	f   *elf.File
+	typ *PlatformType
+}
+
+func newelf(r io.ReaderAt) (tabler, error) {
+	f, err := elf.NewFile(r)
+	if err != nil {
+		return nil, err
+	}
+	typ, err := elfplatform(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &elftbl{f: f, typ: typ}, nil
+}
+
+func (t *elftbl) Close() error { return t.f.Close() }
+
+func (t *elftbl) Sym() ([]byte, error) {
+	sect := t.f.Section(".gosymtab")
+	if sect == nil {
+		// Binaries built with recent Go toolchains carry an empty
+		// (but present) .gosymtab; gosym.NewTable tolerates a nil
+		// slice here just as well, so don't treat this as fatal.
+		return []byte{}, nil
+	}
+	return sect.Data()
+}
+
+func (t *elftbl) Pcln() ([]byte, error) {
+	sect := t.f.Section(".gopclntab")
+	if sect == nil {
+		return nil, ErrNotGoExec
+	}
+	return sect.Data()
+}
+
+func (t *elftbl) Text() (uint64, error) {
+	sect := t.f.Section(".text")
+	if sect == nil {
+		return 0, ErrNotGoExec
+	}
+	return sect.Addr, nil
+}
+
+func (t *elftbl) Type() *PlatformType { return t.typ }
+
+// elfplatform derives the PlatformType of an ELF binary from its
+// machine and data-encoding fields. GOOS frequently cannot be told
+// apart from the ELF header alone (eg linux and android binaries
+// are byte-for-byte indistinguishable at this level), so we only
+// special-case the OSABI values that Go's linker actually sets and
+// otherwise default to "linux".
+func elfplatform(f *elf.File) (*PlatformType, error) {
+	goos := "linux"
+	switch f.OSABI {
+	case elf.ELFOSABI_FREEBSD:
+		goos = "freebsd"
+	case elf.ELFOSABI_NETBSD:
+		goos = "netbsd"
+	case elf.ELFOSABI_OPENBSD:
+		goos = "openbsd"
+	case elf.ELFOSABI_SOLARIS:
+		goos = "solaris"
+	}
+
+	var goarch string
+	switch f.Machine {
+	case elf.EM_386:
+		goarch = "386"
+	case elf.EM_X86_64:
+		goarch = "amd64"
+	case elf.EM_ARM:
+		goarch = "arm"
+	case elf.EM_AARCH64:
+		goarch = "arm64"
+	case elf.EM_LOONGARCH:
+		goarch = "loong64"
+	case elf.EM_MIPS, elf.EM_MIPS_RS3_LE:
+		switch {
+		case f.Class == elf.ELFCLASS64 && f.Data == elf.ELFDATA2LSB:
+			goarch = "mips64le"
+		case f.Class == elf.ELFCLASS64:
+			goarch = "mips64"
+		case f.Data == elf.ELFDATA2LSB:
+			goarch = "mipsle"
+		default:
+			goarch = "mips"
+		}
+	case elf.EM_PPC64:
+		if f.Data == elf.ELFDATA2LSB {
+			goarch = "ppc64le"
+		} else {
+			goarch = "ppc64"
+		}
+	case elf.EM_RISCV:
+		goarch = "riscv64"
+	case elf.EM_S390:
+		goarch = "s390x"
+	case elf.EM_SPARCV9:
+		goarch = "sparc64"
+	default:
+		return nil, ErrNotGoExec
+	}
+
+	if p := PlatformFor(goos, goarch); p != nil {
+		return p, nil
+	}
+	// Not every OSABI/machine combination that we can detect is one
+	// Go actually ships (eg freebsd/s390x doesn't exist); fall back
+	// to a synthetic PlatformType rather than failing outright.
+	return &PlatformType{goos, goarch}, nil
+}
@@ -0,0 +1,84 @@
+package which
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// crossBuild cross-compiles a trivial main package for goos/goarch
+// into a temp directory and returns the resulting binary's path. It
+// skips the test if the local toolchain can't produce that target.
+func crossBuild(t *testing.T, goos, goarch string) string {
+	t.Helper()
+	dir := t.TempDir()
+	src := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(src, []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "prog")
+	cmd := exec.Command("go", "build", "-o", out, src)
+	cmd.Env = append(os.Environ(), "GOOS="+goos, "GOARCH="+goarch)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("cross-compiling for %s/%s: %v\n%s", goos, goarch, err, output)
+	}
+	return out
+}
+
+// TestNewExecCrossPlatform builds a small program for each format
+// NewExec is supposed to fully support (ie everything but wasm) and
+// checks that NewExec both recognizes the platform and can resolve
+// Import from it, so a factory that can detect a format but not
+// actually extract a working symbol table from it (as happened with
+// the PE factory) doesn't go unnoticed.
+func TestNewExecCrossPlatform(t *testing.T) {
+	tests := []struct{ goos, goarch string }{
+		{"linux", "amd64"},
+		{"linux", "arm64"},
+		{"linux", "mips64le"},
+		{"linux", "ppc64le"},
+		{"linux", "riscv64"},
+		{"linux", "s390x"},
+		{"linux", "loong64"},
+		{"darwin", "arm64"},
+		{"windows", "amd64"},
+		{"windows", "arm64"},
+		{"plan9", "amd64"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.goos+"_"+tt.goarch, func(t *testing.T) {
+			bin := crossBuild(t, tt.goos, tt.goarch)
+			ex, err := NewExec(bin)
+			if err != nil {
+				t.Fatalf("NewExec(%s/%s): %v", tt.goos, tt.goarch, err)
+			}
+			if want := PlatformFor(tt.goos, tt.goarch); ex.Type != want {
+				t.Errorf("Type = %v, want %v", ex.Type, want)
+			}
+			if _, err := ex.Import(); err != nil {
+				t.Errorf("Import(%s/%s): %v", tt.goos, tt.goarch, err)
+			}
+		})
+	}
+}
+
+// TestNewExecWasmUnsupported locks in the documented, intentional
+// behavior of the wasm factory: it recognizes the format (and so
+// shouldn't report plain ErrNotGoExec, as if it were an arbitrary
+// non-Go file) but can't build a working Exec from it, which it
+// reports via the distinguishable ErrWasmNoPclntab.
+func TestNewExecWasmUnsupported(t *testing.T) {
+	for _, goos := range []string{"js", "wasip1"} {
+		goos := goos
+		t.Run(goos, func(t *testing.T) {
+			bin := crossBuild(t, goos, "wasm")
+			_, err := NewExec(bin)
+			if !errors.Is(err, ErrWasmNoPclntab) {
+				t.Fatalf("NewExec(%s/wasm) error = %v, want one wrapping ErrWasmNoPclntab", goos, err)
+			}
+		})
+	}
+}
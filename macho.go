@@ -0,0 +1,76 @@
+package which
+
+import (
+	"debug/macho"
+	"io"
+)
+
+type machotbl struct {
+	f   *macho.File
+	typ *PlatformType
+}
+
+func newmacho(r io.ReaderAt) (tabler, error) {
+	f, err := macho.NewFile(r)
+	if err != nil {
+		return nil, err
+	}
+	typ, err := machoplatform(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &machotbl{f: f, typ: typ}, nil
+}
+
+func (t *machotbl) Close() error { return t.f.Close() }
+
+func (t *machotbl) Sym() ([]byte, error) {
+	sect := t.f.Section("__gosymtab")
+	if sect == nil {
+		return []byte{}, nil
+	}
+	return sect.Data()
+}
+
+func (t *machotbl) Pcln() ([]byte, error) {
+	sect := t.f.Section("__gopclntab")
+	if sect == nil {
+		return nil, ErrNotGoExec
+	}
+	return sect.Data()
+}
+
+func (t *machotbl) Text() (uint64, error) {
+	sect := t.f.Section("__text")
+	if sect == nil {
+		return 0, ErrNotGoExec
+	}
+	return sect.Addr, nil
+}
+
+func (t *machotbl) Type() *PlatformType { return t.typ }
+
+// machoplatform derives the PlatformType of a Mach-O binary. All
+// current Mach-O targets supported by Go are Darwin-family
+// (darwin/ios); we tell them apart by CPU type only, since the
+// Mach-O header itself doesn't distinguish macOS from iOS.
+func machoplatform(f *macho.File) (*PlatformType, error) {
+	var goarch string
+	switch f.Cpu {
+	case macho.Cpu386:
+		goarch = "386"
+	case macho.CpuAmd64:
+		goarch = "amd64"
+	case macho.CpuArm:
+		goarch = "arm"
+	case macho.CpuArm64:
+		goarch = "arm64"
+	default:
+		return nil, ErrNotGoExec
+	}
+	if p := PlatformFor("darwin", goarch); p != nil {
+		return p, nil
+	}
+	return &PlatformType{"darwin", goarch}, nil
+}
@@ -0,0 +1,147 @@
+package which
+
+import (
+	"debug/pe"
+	"errors"
+	"io"
+)
+
+type petbl struct {
+	f         *pe.File
+	imageBase uint64
+	typ       *PlatformType
+}
+
+func newpe(r io.ReaderAt) (tabler, error) {
+	f, err := pe.NewFile(r)
+	if err != nil {
+		return nil, err
+	}
+	typ, err := peplatform(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	imageBase, err := peImageBase(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &petbl{f: f, imageBase: imageBase, typ: typ}, nil
+}
+
+func (t *petbl) Close() error { return t.f.Close() }
+
+func (t *petbl) Sym() ([]byte, error) {
+	data, err := loadPETable(t.f, "runtime.symtab", "runtime.esymtab")
+	if err != nil {
+		// 1.3 and earlier used unprefixed names.
+		if data, err = loadPETable(t.f, "symtab", "esymtab"); err != nil {
+			return []byte{}, nil
+		}
+	}
+	return data, nil
+}
+
+func (t *petbl) Pcln() ([]byte, error) {
+	data, err := loadPETable(t.f, "runtime.pclntab", "runtime.epclntab")
+	if err != nil {
+		// 1.3 and earlier used unprefixed names.
+		if data, err = loadPETable(t.f, "pclntab", "epclntab"); err != nil {
+			return nil, ErrNotGoExec
+		}
+	}
+	return data, nil
+}
+
+func (t *petbl) Text() (uint64, error) {
+	sect := t.f.Section(".text")
+	if sect == nil {
+		return 0, ErrNotGoExec
+	}
+	// Section.Addr is an absolute virtual address on ELF and
+	// Mach-O, but PE only records a section's RVA (its offset from
+	// the image's preferred load address); the caller needs the
+	// absolute address, so add ImageBase in ourselves.
+	return t.imageBase + uint64(sect.VirtualAddress), nil
+}
+
+func (t *petbl) Type() *PlatformType { return t.typ }
+
+// peplatform derives the PlatformType of a PE binary from its
+// machine field. PE is Windows-only as far as Go is concerned.
+func peplatform(f *pe.File) (*PlatformType, error) {
+	var goarch string
+	switch f.Machine {
+	case pe.IMAGE_FILE_MACHINE_I386:
+		goarch = "386"
+	case pe.IMAGE_FILE_MACHINE_AMD64:
+		goarch = "amd64"
+	case pe.IMAGE_FILE_MACHINE_ARMNT:
+		goarch = "arm"
+	case pe.IMAGE_FILE_MACHINE_ARM64:
+		goarch = "arm64"
+	default:
+		return nil, ErrNotGoExec
+	}
+	if p := PlatformFor("windows", goarch); p != nil {
+		return p, nil
+	}
+	return &PlatformType{"windows", goarch}, nil
+}
+
+// peImageBase returns the image's preferred load address, needed to
+// turn the RVAs that PE section headers and symbols are expressed
+// in into absolute addresses.
+func peImageBase(f *pe.File) (uint64, error) {
+	switch oh := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		return uint64(oh.ImageBase), nil
+	case *pe.OptionalHeader64:
+		return oh.ImageBase, nil
+	default:
+		return 0, errors.New("which: PE optional header not recognized")
+	}
+}
+
+// loadPETable finds the pair of zero-length COFF symbols the Go
+// linker brackets a table with (eg "runtime.pclntab"/"runtime.epclntab")
+// and returns the bytes of their enclosing section between the two.
+// The Go PE linker doesn't give the symbol/pcln tables their own
+// named sections the way ELF and Mach-O do; they're just a range
+// within a generic section, located via the symbol table instead.
+func loadPETable(f *pe.File, sname, ename string) ([]byte, error) {
+	ssym, err := findPESymbol(f, sname)
+	if err != nil {
+		return nil, err
+	}
+	esym, err := findPESymbol(f, ename)
+	if err != nil {
+		return nil, err
+	}
+	if ssym.SectionNumber != esym.SectionNumber {
+		return nil, errors.New("which: " + sname + " and " + ename + " are not in the same section")
+	}
+	sect := f.Sections[ssym.SectionNumber-1]
+	data, err := sect.Data()
+	if err != nil {
+		return nil, err
+	}
+	if ssym.Value > esym.Value || esym.Value > uint32(len(data)) {
+		return nil, errors.New("which: " + sname + "/" + ename + " out of range of their section")
+	}
+	return data[ssym.Value:esym.Value], nil
+}
+
+func findPESymbol(f *pe.File, name string) (*pe.Symbol, error) {
+	for _, s := range f.Symbols {
+		if s.Name != name {
+			continue
+		}
+		if s.SectionNumber <= 0 || int(s.SectionNumber) > len(f.Sections) {
+			return nil, errors.New("which: symbol " + name + " has an invalid section number")
+		}
+		return s, nil
+	}
+	return nil, errors.New("which: no " + name + " symbol found")
+}
@@ -0,0 +1,124 @@
+package which
+
+import (
+	"debug/plan9obj"
+	"errors"
+	"io"
+)
+
+type plan9tbl struct {
+	f         *plan9obj.File
+	textStart uint64
+	typ       *PlatformType
+}
+
+func newplan9(r io.ReaderAt) (tabler, error) {
+	f, err := plan9obj.NewFile(r)
+	if err != nil {
+		return nil, err
+	}
+	typ, err := plan9platform(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &plan9tbl{f: f, textStart: f.LoadAddress + f.HdrSize, typ: typ}, nil
+}
+
+func (t *plan9tbl) Close() error { return t.f.Close() }
+
+func (t *plan9tbl) Sym() ([]byte, error) {
+	data, err := t.loadTable("runtime.symtab", "runtime.esymtab")
+	if err != nil {
+		// 1.3 and earlier used unprefixed names.
+		if data, err = t.loadTable("symtab", "esymtab"); err != nil {
+			return []byte{}, nil
+		}
+	}
+	return data, nil
+}
+
+func (t *plan9tbl) Pcln() ([]byte, error) {
+	data, err := t.loadTable("runtime.pclntab", "runtime.epclntab")
+	if err != nil {
+		// 1.3 and earlier used unprefixed names.
+		if data, err = t.loadTable("pclntab", "epclntab"); err != nil {
+			return nil, ErrNotGoExec
+		}
+	}
+	return data, nil
+}
+
+func (t *plan9tbl) Text() (uint64, error) {
+	if t.f.Section("text") == nil {
+		return 0, ErrNotGoExec
+	}
+	return t.textStart, nil
+}
+
+func (t *plan9tbl) Type() *PlatformType { return t.typ }
+
+// loadTable finds the pair of zero-length symbols the Go linker
+// brackets a table with (eg "runtime.pclntab"/"runtime.epclntab")
+// and returns the bytes between them out of the text section. Like
+// PE, Plan 9 a.out doesn't give the symbol/pcln tables their own
+// named sections; they're a range within "text", located via the
+// symbol table instead, with symbol values expressed as absolute
+// addresses that need textStart subtracted back out to index into
+// the section's raw bytes.
+func (t *plan9tbl) loadTable(sname, ename string) ([]byte, error) {
+	ssym, err := t.findSymbol(sname)
+	if err != nil {
+		return nil, err
+	}
+	esym, err := t.findSymbol(ename)
+	if err != nil {
+		return nil, err
+	}
+	sect := t.f.Section("text")
+	if sect == nil {
+		return nil, ErrNotGoExec
+	}
+	data, err := sect.Data()
+	if err != nil {
+		return nil, err
+	}
+	start, end := ssym.Value-t.textStart, esym.Value-t.textStart
+	if start > end || end > uint64(len(data)) {
+		return nil, errors.New("which: " + sname + "/" + ename + " out of range of the text section")
+	}
+	return data[start:end], nil
+}
+
+func (t *plan9tbl) findSymbol(name string) (*plan9obj.Sym, error) {
+	syms, err := t.f.Symbols()
+	if err != nil {
+		return nil, err
+	}
+	for i := range syms {
+		if syms[i].Name == name {
+			return &syms[i], nil
+		}
+	}
+	return nil, errors.New("which: no " + name + " symbol found")
+}
+
+// plan9platform derives the PlatformType of a Plan 9 a.out binary
+// from its magic number. Note that plan9obj.MagicAMD64 already has
+// the Magic64 bit set (that's what makes it the 64-bit one of the
+// three), so we match the raw Magic field directly rather than
+// masking Magic64 out of it first.
+func plan9platform(f *plan9obj.File) (*PlatformType, error) {
+	var goarch string
+	switch f.Magic {
+	case plan9obj.Magic386:
+		goarch = "386"
+	case plan9obj.MagicAMD64:
+		goarch = "amd64"
+	case plan9obj.MagicARM:
+		goarch = "arm"
+	default:
+		return nil, ErrNotGoExec
+	}
+	return mustPlatform("plan9", goarch), nil
+}
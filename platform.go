@@ -0,0 +1,128 @@
+package which
+
+import "fmt"
+
+// PlatformType represents the target platform of the executable.
+type PlatformType struct {
+	GOOS   string // target operating system
+	GOARCH string // target architecture
+}
+
+// String gives Go platform string.
+func (typ PlatformType) String() string {
+	return typ.GOOS + "_" + typ.GOARCH
+}
+
+// platforms lists every GOOS/GOARCH pair that a current Go toolchain
+// can produce, mirroring the okgoos/okgoarch tables in cmd/dist. It
+// is the single source of truth for PlatformType values; the
+// exported Platform* vars below (except the deprecated
+// PlatformDarwin386) and Platforms()/PlatformFor() are all derived
+// from it instead of being hand-declared separately. It is sorted by
+// GOOS alphabetically and, within each GOOS, by cmd/dist's okgoarch
+// order.
+var platforms = []*PlatformType{
+	{"aix", "ppc64"},
+	{"android", "386"},
+	{"android", "amd64"},
+	{"android", "arm"},
+	{"android", "arm64"},
+	{"darwin", "amd64"},
+	{"darwin", "arm64"},
+	{"dragonfly", "amd64"},
+	{"freebsd", "386"},
+	{"freebsd", "amd64"},
+	{"freebsd", "arm"},
+	{"freebsd", "arm64"},
+	{"freebsd", "riscv64"},
+	{"illumos", "amd64"},
+	{"ios", "amd64"},
+	{"ios", "arm64"},
+	{"js", "wasm"},
+	{"linux", "386"},
+	{"linux", "amd64"},
+	{"linux", "arm"},
+	{"linux", "arm64"},
+	{"linux", "loong64"},
+	{"linux", "mips"},
+	{"linux", "mipsle"},
+	{"linux", "mips64"},
+	{"linux", "mips64le"},
+	{"linux", "ppc64"},
+	{"linux", "ppc64le"},
+	{"linux", "riscv64"},
+	{"linux", "s390x"},
+	{"linux", "sparc64"},
+	{"netbsd", "386"},
+	{"netbsd", "amd64"},
+	{"netbsd", "arm"},
+	{"netbsd", "arm64"},
+	{"openbsd", "386"},
+	{"openbsd", "amd64"},
+	{"openbsd", "arm"},
+	{"openbsd", "arm64"},
+	{"openbsd", "mips64"},
+	{"plan9", "386"},
+	{"plan9", "amd64"},
+	{"plan9", "arm"},
+	{"solaris", "amd64"},
+	{"wasip1", "wasm"},
+	{"windows", "386"},
+	{"windows", "amd64"},
+	{"windows", "arm"},
+	{"windows", "arm64"},
+}
+
+// Platforms returns every platform type that NewExec and friends
+// know how to recognize, in the order of the platforms table above.
+func Platforms() []*PlatformType {
+	out := make([]*PlatformType, len(platforms))
+	copy(out, platforms)
+	return out
+}
+
+// PlatformFor looks up the PlatformType for a given GOOS/GOARCH
+// pair. It returns nil if the pair is not one we know about.
+func PlatformFor(goos, goarch string) *PlatformType {
+	for _, p := range platforms {
+		if p.GOOS == goos && p.GOARCH == goarch {
+			return p
+		}
+	}
+	return nil
+}
+
+// mustPlatform is like PlatformFor but panics on an unknown pair; it
+// is only used with fixed pairs chosen by this package (the
+// package-level Platform* vars below, plan9platform, and wasmparse),
+// where a miss would be a bug in this package, not bad input.
+func mustPlatform(goos, goarch string) *PlatformType {
+	p := PlatformFor(goos, goarch)
+	if p == nil {
+		panic(fmt.Sprintf("which: internal error: %s/%s is not in platforms", goos, goarch))
+	}
+	return p
+}
+
+var (
+	// PlatformDarwin386 represents the darwin_386 target arch.
+	//
+	// Deprecated: Go no longer builds 32-bit Darwin binaries, so
+	// this is not one of the platforms reported by Platforms(). It
+	// is kept only so existing code that refers to it still builds.
+	PlatformDarwin386 = &PlatformType{"darwin", "386"}
+	// PlatformDarwinAMD64 represents the darwin_amd64 target arch.
+	PlatformDarwinAMD64 = mustPlatform("darwin", "amd64")
+	// PlatformFreeBSD386 represents the freebsd_386 target arch.
+	PlatformFreeBSD386 = mustPlatform("freebsd", "386")
+	// PlatformFreeBSDAMD64 represents the freebsd_amd64 target arch.
+	PlatformFreeBSDAMD64 = mustPlatform("freebsd", "amd64")
+	// PlatformLinux386 represents the linux_386 target arch.
+	PlatformLinux386 = mustPlatform("linux", "386")
+	// PlatformLinuxAMD64 represents the linux_amd64 target arch.
+	PlatformLinuxAMD64 = mustPlatform("linux", "amd64")
+	// PlatformWindows386 represents the windows_386 target arch.
+	PlatformWindows386 = mustPlatform("windows", "386")
+	// PlatformWindowsAMD64 represents the windows_amd64 target arch.
+	PlatformWindowsAMD64 = mustPlatform("windows", "amd64")
+)
@@ -0,0 +1,78 @@
+package which
+
+import (
+	"strings"
+	"time"
+)
+
+// VCS returns the version control provenance that the Go linker
+// recorded when this executable was built: which VCS was used, the
+// revision (commit) the source tree was checked out at, whether
+// that tree had uncommitted local modifications, and the time
+// associated with the revision. This is the same information "go
+// version -m" prints, without needing to shell out to it.
+//
+// It requires the same build info that BuildInfo needs, and returns
+// whatever error BuildInfo returns if that isn't available. A zero
+// Time or empty system/revision means the linker didn't record that
+// particular piece (eg the binary wasn't built from a VCS checkout).
+func (ex *Exec) VCS() (system, revision string, modified bool, when time.Time, err error) {
+	bi, err := ex.BuildInfo()
+	if err != nil {
+		return "", "", false, time.Time{}, err
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs":
+			system = s.Value
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			modified = s.Value == "true"
+		case "vcs.time":
+			// Best-effort; an unparseable or absent timestamp just
+			// leaves when as the zero Time.
+			when, _ = time.Parse(time.RFC3339, s.Value)
+		}
+	}
+	return system, revision, modified, when, nil
+}
+
+// BuildSettings is a typed view of the handful of BuildInfo.Settings
+// keys that callers most often want, so they don't each have to
+// string-match the same Settings slice themselves.
+type BuildSettings struct {
+	BuildMode  string   // -buildmode, eg "exe" or "pie"
+	GOFLAGS    string   // GOFLAGS in effect during the build
+	Trimpath   bool     // -trimpath
+	CgoEnabled bool     // CGO_ENABLED
+	Tags       []string // -tags, split on commas
+}
+
+// BuildSettings reads the build settings embedded in this executable
+// by the Go linker. It returns whatever error BuildInfo returns if
+// that information isn't available.
+func (ex *Exec) BuildSettings() (BuildSettings, error) {
+	bi, err := ex.BuildInfo()
+	if err != nil {
+		return BuildSettings{}, err
+	}
+	var bs BuildSettings
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "-buildmode":
+			bs.BuildMode = s.Value
+		case "GOFLAGS":
+			bs.GOFLAGS = s.Value
+		case "-trimpath":
+			bs.Trimpath = s.Value == "true"
+		case "CGO_ENABLED":
+			bs.CgoEnabled = s.Value == "1"
+		case "-tags":
+			if s.Value != "" {
+				bs.Tags = strings.Split(s.Value, ",")
+			}
+		}
+	}
+	return bs, nil
+}
@@ -0,0 +1,271 @@
+package which
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// wasmtbl implements tabler for WebAssembly binaries produced by
+// GOARCH=wasm builds (GOOS=js or GOOS=wasip1).
+//
+// This is GOOS/GOARCH detection only, not full Exec/Import support.
+// Unlike ELF, Mach-O, PE, and Plan 9 a.out, the Go wasm linker does
+// not emit the symbol table and pcln table as named sections or
+// reference them via symbols; they're written into the wasm data
+// section as raw bytes at an address only recoverable by also
+// reading the binary's DWARF or the linker's internal layout,
+// neither of which this package implements. So for every ordinary
+// GOARCH=wasm build, Pcln fails with ErrWasmNoPclntab (wrapped by
+// newtbl), and NewExec/Import don't work for wasm binaries. What we
+// can still do from the module header and import section alone is
+// recognize the file as wasm and report its PlatformType, which
+// newwasm does; Pcln/Sym only succeed at all for the hypothetical
+// case of a wasm binary that does carry "gopclntab"/"gosymtab"
+// custom sections.
+type wasmtbl struct {
+	pclntab []byte
+	symtab  []byte
+	typ     *PlatformType
+}
+
+const (
+	wasmMagic   = 0x6d736100 // "\0asm", little-endian
+	wasmVersion = 1
+
+	wasmSecCustom = 0
+	wasmSecImport = 2
+
+	// wasmMaxSize bounds the io.SectionReader we scan the module
+	// through. We don't know the binary's real length here (newwasm
+	// only gets an io.ReaderAt, not a size), so we just pick
+	// something no real wasm module will ever reach; the underlying
+	// ReaderAt still reports io.EOF at the real end of the data.
+	wasmMaxSize = 1 << 40
+)
+
+func newwasm(r io.ReaderAt) (tabler, error) {
+	typ, pclntab, symtab, err := wasmparse(io.NewSectionReader(r, 0, wasmMaxSize))
+	if err != nil {
+		return nil, err
+	}
+	return &wasmtbl{pclntab: pclntab, symtab: symtab, typ: typ}, nil
+}
+
+func (t *wasmtbl) Close() error { return nil }
+
+func (t *wasmtbl) Sym() ([]byte, error) {
+	return t.symtab, nil
+}
+
+func (t *wasmtbl) Pcln() ([]byte, error) {
+	if t.pclntab == nil {
+		return nil, ErrWasmNoPclntab
+	}
+	return t.pclntab, nil
+}
+
+func (t *wasmtbl) Text() (uint64, error) {
+	// wasm has no single linear "text start" address the way ELF
+	// and friends do; code lives in per-function bodies indexed by
+	// function number, not by a flat address space. Since we can
+	// never produce a usable pclntab anyway (see the comment on
+	// wasmtbl), 0 is as good as anything else here.
+	return 0, nil
+}
+
+func (t *wasmtbl) Type() *PlatformType { return t.typ }
+
+// wasmparse reads just enough of the WebAssembly binary format
+// (module header, then a scan of the custom and import sections) to
+// identify the file as a wasm module, guess its GOOS from the
+// imports it declares, and pick up a gopclntab/gosymtab custom
+// section if one is present.
+func wasmparse(r io.Reader) (typ *PlatformType, pclntab, symtab []byte, err error) {
+	br := bufio.NewReader(r)
+
+	var header [8]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		return nil, nil, nil, ErrNotGoExec
+	}
+	if binary.LittleEndian.Uint32(header[0:4]) != wasmMagic ||
+		binary.LittleEndian.Uint32(header[4:8]) != wasmVersion {
+		return nil, nil, nil, ErrNotGoExec
+	}
+
+	goos := "js"
+	for {
+		id, err := br.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, nil, ErrNotGoExec
+		}
+		size, err := readUleb128(br)
+		if err != nil {
+			return nil, nil, nil, ErrNotGoExec
+		}
+		body := make([]byte, size)
+		if _, err := io.ReadFull(br, body); err != nil {
+			return nil, nil, nil, ErrNotGoExec
+		}
+		switch id {
+		case wasmSecCustom:
+			name, rest, ok := wasmReadString(body)
+			if !ok {
+				continue
+			}
+			switch name {
+			case "gopclntab":
+				pclntab = rest
+			case "gosymtab":
+				symtab = rest
+			}
+		case wasmSecImport:
+			if wasmImportsWASI(body) {
+				goos = "wasip1"
+			}
+		}
+	}
+
+	return mustPlatform(goos, "wasm"), pclntab, symtab, nil
+}
+
+// wasmImportsWASI reports whether a wasm import section's raw body
+// imports anything from the "wasi_snapshot_preview1" module, which
+// is how the GOOS=wasip1 port's runtime talks to its host.
+func wasmImportsWASI(body []byte) bool {
+	rd := newByteReader(body)
+	count, err := readUleb128(rd)
+	if err != nil {
+		return false
+	}
+	for i := uint64(0); i < count; i++ {
+		mod, ok := wasmReadLenString(rd)
+		if !ok {
+			return false
+		}
+		if mod == "wasi_snapshot_preview1" {
+			return true
+		}
+		if _, ok := wasmReadLenString(rd); !ok { // field name
+			return false
+		}
+		kind, err := rd.ReadByte()
+		if err != nil {
+			return false
+		}
+		if !wasmSkipImportDesc(rd, kind) {
+			return false
+		}
+	}
+	return false
+}
+
+// wasmSkipImportDesc consumes the kind-specific remainder of a
+// single import entry so the reader is left positioned at the next
+// one.
+func wasmSkipImportDesc(rd *byteReader, kind byte) bool {
+	switch kind {
+	case 0x00: // function: typeidx
+		_, err := readUleb128(rd)
+		return err == nil
+	case 0x01: // table: reftype, limits
+		if _, err := rd.ReadByte(); err != nil {
+			return false
+		}
+		return wasmSkipLimits(rd)
+	case 0x02: // memory: limits
+		return wasmSkipLimits(rd)
+	case 0x03: // global: valtype, mutability
+		if _, err := rd.ReadByte(); err != nil {
+			return false
+		}
+		_, err := rd.ReadByte()
+		return err == nil
+	}
+	return false
+}
+
+func wasmSkipLimits(rd *byteReader) bool {
+	flags, err := rd.ReadByte()
+	if err != nil {
+		return false
+	}
+	if _, err := readUleb128(rd); err != nil { // min
+		return false
+	}
+	if flags&0x1 != 0 {
+		if _, err := readUleb128(rd); err != nil { // max
+			return false
+		}
+	}
+	return true
+}
+
+// wasmReadString splits a custom section's body into its name and
+// the bytes that follow it.
+func wasmReadString(body []byte) (name string, rest []byte, ok bool) {
+	rd := newByteReader(body)
+	name, ok = wasmReadLenString(rd)
+	if !ok {
+		return "", nil, false
+	}
+	return name, body[rd.pos:], true
+}
+
+func wasmReadLenString(rd *byteReader) (string, bool) {
+	n, err := readUleb128(rd)
+	if err != nil || rd.pos+int(n) > len(rd.buf) {
+		return "", false
+	}
+	s := string(rd.buf[rd.pos : rd.pos+int(n)])
+	rd.pos += int(n)
+	return s, true
+}
+
+// byteReader is a tiny io.ByteReader over an in-memory slice, used
+// by the LEB128 helpers that were written against io.ByteReader so
+// they work for both the streaming section scan and this in-memory
+// re-parse of a section's body.
+type byteReader struct {
+	buf []byte
+	pos int
+}
+
+func newByteReader(buf []byte) *byteReader { return &byteReader{buf: buf} }
+
+func (r *byteReader) ReadByte() (byte, error) {
+	if r.pos >= len(r.buf) {
+		return 0, io.EOF
+	}
+	b := r.buf[r.pos]
+	r.pos++
+	return b, nil
+}
+
+var errLeb128Overflow = errors.New("which: malformed wasm LEB128 value")
+
+// readUleb128 reads an unsigned LEB128-encoded integer, the integer
+// encoding used throughout the wasm binary format for section sizes,
+// vector lengths, and string lengths.
+func readUleb128(r io.ByteReader) (uint64, error) {
+	var result uint64
+	var shift uint
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if shift >= 64 {
+			return 0, errLeb128Overflow
+		}
+		result |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return result, nil
+		}
+		shift += 7
+	}
+}
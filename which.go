@@ -3,15 +3,14 @@ package which
 import (
 	"debug/gosym"
 	"errors"
-	"go/build"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
 )
 
-// TODO(rjeczalik): support all platform types
-
 func init() {
 	// Add $GOROOT and $GOROOT_FINAL to the filtered paths.
 	goroot := runtime.GOROOT()
@@ -20,7 +19,11 @@ func init() {
 		filtered[runtime.GOROOT()] = struct{}{} // $GOROOT_FINAL
 		os.Setenv("GOROOT", goroot)
 	}
-	// Make the order of file factory methods platform-specific.
+	// Make the order of file factory methods platform-specific, so
+	// that the format native to the host is tried first. newplan9
+	// and newwasm are cheap enough to just tack on at the end
+	// everywhere, since plan9 and wasm binaries never show up as
+	// the host format.
 	switch runtime.GOOS {
 	case "darwin":
 		alltbl = append(alltbl, newmacho, newelf, newpe)
@@ -29,6 +32,7 @@ func init() {
 	default:
 		alltbl = append(alltbl, newelf, newmacho, newpe)
 	}
+	alltbl = append(alltbl, newplan9, newwasm)
 }
 
 type tabler interface {
@@ -39,8 +43,15 @@ type tabler interface {
 	Type() *PlatformType
 }
 
-// All supported symbol table builders.
-var alltbl []func(string) (tabler, error)
+// All supported symbol table builders. Each takes an io.ReaderAt
+// rather than a path so that NewExec, NewExecReader, and NewExecFile
+// can all funnel through the same factories: debug/elf, debug/macho,
+// debug/pe, and debug/plan9obj all have a NewFile(io.ReaderAt)
+// constructor that does no I/O of its own beyond what's needed to
+// parse the format, which is exactly what we want for binaries that
+// live in a tar layer, an object store, or behind an HTTP range
+// reader instead of on local disk.
+var alltbl []func(io.ReaderAt) (tabler, error)
 
 // A path is discarded if it contains any of the filtered strings.
 // TODO(rjeczalik): add $HOME/.gvm/gos?
@@ -56,50 +67,91 @@ var (
 	ErrNotGoExec = errors.New("which: not a Go executable")
 	// ErrGuessFail is an error.
 	ErrGuessFail = errors.New("which: unable to guess an import path of the main package")
-)
-
-// PlatformType represents the target platform of the executable.
-type PlatformType struct {
-	GOOS   string // target operating system
-	GOARCH string // target architecture
-}
-
-// String gives Go platform string.
-func (typ PlatformType) String() string {
-	return typ.GOOS + "_" + typ.GOARCH
-}
-
-var (
-	// PlatformDarwin386 represents the darwin_386 target arch.
-	PlatformDarwin386 = &PlatformType{"darwin", "386"}
-	// PlatformDarwinAMD64 represents the darwin_amd64 target arch.
-	PlatformDarwinAMD64 = &PlatformType{"darwin", "amd64"}
-	// PlatformFreeBSD386 represents the freebsd_386 target arch.
-	PlatformFreeBSD386 = &PlatformType{"freebsd", "386"}
-	// PlatformFreeBSDAMD64 represents the freebsd_amd64 target arch.
-	PlatformFreeBSDAMD64 = &PlatformType{"freebsd", "amd64"}
-	// PlatformLinux386 represents the linux_386 target arch.
-	PlatformLinux386 = &PlatformType{"linux", "386"}
-	// PlatformLinuxAMD64 represents the linux_amd64 target arch.
-	PlatformLinuxAMD64 = &PlatformType{"linux", "amd64"}
-	// PlatformWindows386 represents the windows_386 target arch.
-	PlatformWindows386 = &PlatformType{"windows", "386"}
-	// PlatformWindowsAMD64 represents the windows_amd64 target arch.
-	PlatformWindowsAMD64 = &PlatformType{"windows", "amd64"}
+	// ErrWasmNoPclntab is returned when a file is recognized as a
+	// wasm binary (correct magic/version, at least identifying its
+	// GOOS via its imports) but carries no pcln table we can find.
+	// As of Go 1.21 this is every ordinary GOARCH=wasm build: the
+	// wasm linker doesn't give the table a named section or a
+	// symbol the way every other linker does, so newwasm can only
+	// ever identify the platform, not build a working Exec. See the
+	// wasmtbl doc comment for more.
+	ErrWasmNoPclntab = errors.New("which: recognized a wasm binary, but it has no extractable pcln table")
 )
 
 // Exec represents a single Go executable file.
 type Exec struct {
-	Path  string        // Path to the executable.
+	Path  string        // Path to the executable, if known.
 	Type  *PlatformType // Fileutable file format.
 	table *gosym.Table
+	r     io.ReaderAt // underlying content, if set (NewExecReader, NewExecFile); BuildInfo reads it in preference to Path
 }
 
 // NewExec tries to detect executable type for the given path and returns
 // a new executable. It fails if file does not exist, is not a Go executable or
 // it's unable to parse the file format.
 func NewExec(path string) (*Exec, error) {
-	typ, symtab, pclntab, text, err := newtbl(path)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	ex, err := newExec(f)
+	if err != nil {
+		return nil, err
+	}
+	ex.Path = path
+	return ex, nil
+}
+
+// NewExecReader is like NewExec, but reads the executable from r
+// instead of from a path on disk. This is for callers inspecting
+// binaries that live somewhere other than the local filesystem, eg a
+// tar layer, an object store, or an HTTP range reader, who would
+// otherwise have to spill the binary to a temp file first. r must
+// remain valid for as long as the returned Exec is in use, since
+// BuildInfo re-reads from it on demand. size is the total length of
+// the executable; it isn't used directly by the format parsers
+// (which are self-delimiting), but is checked for sanity.
+//
+// The returned Exec has an empty Path, since there is none. This is
+// fine for BuildInfo/VCS/BuildSettings, which don't need one, but it
+// means Import's pclntab-heuristic fallback (used for binaries that
+// predate buildinfo or have had it stripped) has no executable name
+// to work from and will likely fail where NewExec would have
+// succeeded. If the caller knows a reasonable stand-in name (eg the
+// tar entry name or object key), set it on ex.Path before calling
+// Import to give the fallback heuristic something to work with.
+func NewExecReader(r io.ReaderAt, size int64) (*Exec, error) {
+	if size <= 0 {
+		return nil, ErrNotGoExec
+	}
+	ex, err := newExec(r)
+	if err != nil {
+		return nil, err
+	}
+	ex.r = r
+	return ex, nil
+}
+
+// NewExecFile is like NewExec, but takes an already-open *os.File.
+// Unlike NewExec, it does not close f; the caller retains ownership
+// and should close it once the returned Exec is no longer needed.
+func NewExecFile(f *os.File) (*Exec, error) {
+	ex, err := newExec(f)
+	if err != nil {
+		return nil, err
+	}
+	ex.Path = f.Name()
+	ex.r = f
+	return ex, nil
+}
+
+// newExec does the actual format detection and gosym table building
+// that NewExec, NewExecReader, and NewExecFile all need; it's up to
+// the caller to decide what (if anything) to record in the returned
+// Exec's Path and r fields.
+func newExec(r io.ReaderAt) (*Exec, error) {
+	typ, symtab, pclntab, text, err := newtbl(r)
 	if err != nil {
 		return nil, err
 	}
@@ -111,27 +163,29 @@ func NewExec(path string) (*Exec, error) {
 	if err != nil {
 		return nil, ErrNotGoExec
 	}
-	return &Exec{Path: path, Type: typ, table: tab}, nil
+	return &Exec{Type: typ, table: tab}, nil
 }
 
 // Import gives the import path of main package of given executable. It returns
 // non-nil error when it fails to guess the exact path.
 //
-// TODO(cks): support Go modules somehow, since they may not be built
-// in local directory trees that we can understand to extract a
-// package name from.
-//
-// rsc.io/goversion/version can extract module information from binaries
-// that contain it, and runtime/debug.ReadBuildInfo extracts it from
-// the current program, but there doesn't seem to be an official
-// interface for getting it from files. The module info is also
-// apparently stored purely as a string, and would have to be parsed
-// into the runtime/debug.BuildInfo form. Summary: it would be
-// fragile.
+// If the executable carries Go module build information (Go 1.13+,
+// built in module mode), Import reports BuildInfo.Path, which is the
+// authoritative answer and understands modules outside any local
+// $GOPATH/src or $GOPATH/pkg/mod tree. The pclntab/main.main/genpkgpath
+// heuristic below is only used as a fallback, for binaries that predate
+// Go 1.13 or that have had their build info stripped.
 func (ex *Exec) Import() (string, error) {
+	if bi, err := ex.BuildInfo(); err == nil && bi.Path != "" {
+		return bi.Path, nil
+	}
+
 	var dirs = make(map[string]struct{})
 	name := filepath.Base(ex.Path)
-	if ex.Type == PlatformWindows386 || ex.Type == PlatformWindowsAMD64 {
+	// Check GOOS rather than enumerating Platform* pointer constants,
+	// so this doesn't miss windows/arm, windows/arm64, or any other
+	// windows/GOARCH that platform.go's table grows in the future.
+	if ex.Type != nil && ex.Type.GOOS == "windows" {
 		name = strings.TrimSuffix(name, ".exe")
 	}
 
@@ -175,14 +229,17 @@ func Import(path string) (string, error) {
 	return ex.Import()
 }
 
-func newtbl(path string) (typ *PlatformType, symtab, pclntab []byte, text uint64, err error) {
+func newtbl(r io.ReaderAt) (typ *PlatformType, symtab, pclntab []byte, text uint64, err error) {
 	var tbl tabler
 	fail := func() {
-		err = errors.New("which: unable to read Go symbol table: " + err.Error())
+		// %w rather than a flat string so callers can still
+		// errors.Is() their way to a sentinel like
+		// ErrWasmNoPclntab through this wrapper.
+		err = fmt.Errorf("which: unable to read Go symbol table: %w", err)
 		tbl.Close()
 	}
 	for _, newt := range alltbl {
-		if tbl, err = newt(path); err != nil {
+		if tbl, err = newt(r); err != nil {
 			err = ErrNotGoExec
 			continue
 		}
@@ -240,15 +297,13 @@ func guesspkg(name string, dirs map[string]struct{}) (pkg string, unique bool) {
 	return
 }
 
-// getgopath gets a slice of directories that are GOPATH.
-// $GOPATH can be a colon-separated list of paths, so we must cope.
-// This requires Go 1.8+ for go/build's Default.GOPATH.
+// getgopath gets a slice of directories that are GOPATH, using
+// buildContext (by default go/build.Default, which already reads
+// $GOPATH or falls back to its own default for us). We go through
+// filepath.SplitList rather than splitting on ':' ourselves so this
+// copes with Windows' ';'-separated GOPATH too.
 func getgopath() []string {
-	gopath := os.Getenv("GOPATH")
-	if gopath == "" {
-		gopath = build.Default.GOPATH
-	}
-	return strings.Split(gopath, ":")
+	return filepath.SplitList(buildContext.GOPATH)
 }
 
 // pathtomodule transforms directory paths from underneath
@@ -297,37 +352,36 @@ var mod = filepath.FromSlash("/pkg/mod/")
 // generally embed the non-symlink path even if $GOPATH or $HOME
 // involves a symlink.
 func genpkgpath(name, dir string) (string, error) {
-	var checkpaths []string
-	checkpaths = []string{runtime.GOROOT()}
-	checkpaths = append(checkpaths, getgopath()...)
-
 	if nd, err := filepath.EvalSymlinks(dir); err == nil {
 		dir = nd
 	}
-	for _, path := range checkpaths {
-		if abs, err := filepath.EvalSymlinks(path); err == nil {
-			path = abs
-		}
-		pth := path + string(os.PathSeparator)
-		if !strings.HasPrefix(dir, pth) {
-			continue
+
+	// First, look for a straightforward build, which has a
+	// directory name under one of GOROOT/src or $GOPATH/src. This
+	// may be a non-module build or a module build; we can't
+	// actually tell from the directory path alone. However in
+	// either case it's been built directly from the source code
+	// there. buildContext.SrcDirs() already does the GOROOT vs.
+	// GOPATH and path-list-separator handling for us.
+	for _, spth := range buildContext.SrcDirs() {
+		if abs, err := filepath.EvalSymlinks(spth); err == nil {
+			spth = abs
 		}
-		// First, look for a straightforward build, which has
-		// a directory name under $GOPATH/src/. This may be a
-		// non-module build or a module build; we can't
-		// actually tell from the directory path
-		// alone. However in either case it's been built
-		// directly from the source code there.
-		spth := pth + "src" + string(os.PathSeparator)
+		spth += string(os.PathSeparator)
 		if strings.HasPrefix(dir, spth) {
 			return dir[len(spth):], nil
 		}
-		// Second, look for a module build that was done
-		// directly through 'go get <something>@<version>',
-		// which has a directory name under $GOPATH/pkg/mod/.
-		// In this case we report the package name with the
-		// module version.
-		mpth := filepath.Join(pth, "pkg", "mod") + string(os.PathSeparator)
+	}
+
+	// Second, look for a module build that was done directly
+	// through 'go get <something>@<version>', which has a
+	// directory name under $GOPATH/pkg/mod/. In this case we
+	// report the package name with the module version.
+	for _, path := range getgopath() {
+		if abs, err := filepath.EvalSymlinks(path); err == nil {
+			path = abs
+		}
+		mpth := filepath.Join(path, "pkg", "mod") + string(os.PathSeparator)
 		if strings.HasPrefix(dir, mpth) {
 			return pathtomodule(dir[len(mpth):]), nil
 		}